@@ -2,30 +2,59 @@ package harestorage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
 	"path"
+	"strings"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 )
 
-var _ Storage = (*GCSStorage)(nil)
+var (
+	_ Storage   = (*GCSStorage)(nil)
+	_ URLSigner = (*GCSStorage)(nil)
+)
 
 // GCSStorage implements the Storage interface for Google Cloud Storage (GCS).
 type GCSStorage struct {
-	client     *storage.Client
-	bucketName string
-	name       string
+	client      *storage.Client
+	bucketName  string
+	name        string
+	retryPolicy RetryPolicy
+}
+
+// GCSOption configures a GCSStorage constructed by NewGCSStorage.
+type GCSOption func(*GCSStorage)
+
+// WithRetryPolicy overrides DefaultRetryPolicy for a GCSStorage's network operations.
+func WithRetryPolicy(policy RetryPolicy) GCSOption {
+	return func(s *GCSStorage) {
+		s.retryPolicy = policy
+	}
 }
 
 // NewGCSStorage creates a new GCSStorage instance with the specified client, bucket name, and storage name.
-func NewGCSStorage(client *storage.Client, bucketName string, name string) *GCSStorage {
-	return &GCSStorage{
-		client:     client,
-		bucketName: bucketName,
-		name:       name,
+func NewGCSStorage(client *storage.Client, bucketName string, name string, opts ...GCSOption) *GCSStorage {
+	s := &GCSStorage{
+		client:      client,
+		bucketName:  bucketName,
+		name:        name,
+		retryPolicy: DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // Name returns the name (identifier) of this storage.
@@ -44,14 +73,40 @@ func (s *GCSStorage) Get(ctx context.Context, name string) (io.ReadCloser, error
 		return nil, fmt.Errorf("name required")
 	}
 
-	r, err := s.client.Bucket(s.bucketName).Object(name).NewReader(ctx)
+	var r io.ReadCloser
+
+	err = withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+		var err error
+
+		r, err = s.client.Bucket(s.bucketName).Object(name).NewReader(ctx)
+
+		return err
+	})
 	if err != nil {
 		fullPath := s.PathJoin(s.bucketName, name)
 
 		return nil, fmt.Errorf("failed to open file %q: %w", fullPath, err)
 	}
 
-	return r, err
+	return r, nil
+}
+
+// isRetryableGCSError reports whether err is a transient GCS error worth retrying.
+func isRetryableGCSError(err error) bool {
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		switch gErr.Code {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+
+		return false
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 // Put saves data to the storage with the specified name.
@@ -65,30 +120,375 @@ func (s *GCSStorage) Put(ctx context.Context, name string, r io.Reader, opts *Pu
 		return 0, fmt.Errorf("name required")
 	}
 
-	w := s.client.Bucket(s.bucketName).Object(name).NewWriter(ctx)
+	if opts != nil && opts.MaxConcurrency > 1 {
+		return s.putComposite(ctx, name, r, opts)
+	}
 
-	defer w.Close()
+	obj := s.client.Bucket(s.bucketName).Object(name)
 
 	if opts != nil {
-		if opts.ContentType != "" {
-			w.ContentType = opts.ContentType
+		if cond, ok := putConditions(opts); ok {
+			obj = obj.If(cond)
 		}
+	}
 
-		if len(opts.Metadata) > 0 {
-			w.Metadata = opts.Metadata
-		}
+	seeker, seekable := r.(io.ReadSeeker)
+
+	policy := s.retryPolicy
+	if !seekable && (opts == nil || !opts.Retryable) {
+		policy.MaxAttempts = 1
+	}
+
+	if opts != nil && (opts.IfNotExists || opts.IfGenerationMatch != nil) {
+		// A retry can't tell a lost ack from a real conflict: if the first
+		// attempt actually succeeded, retrying a conditional write would hit
+		// the object it just created and return a spurious
+		// ErrPreconditionFailed for a Put that succeeded.
+		policy.MaxAttempts = 1
 	}
 
-	size, err := io.Copy(w, r)
+	var size int64
+
+	err = withRetry(ctx, policy, isRetryableGCSError, func() error {
+		if seekable {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+
+		w := obj.NewWriter(ctx)
+
+		if opts != nil {
+			if opts.ContentType != "" {
+				w.ContentType = opts.ContentType
+			}
+
+			if len(opts.Metadata) > 0 {
+				w.Metadata = opts.Metadata
+			}
+
+			if opts.ChunkSize > 0 {
+				w.ChunkSize = int(opts.ChunkSize)
+			}
+		}
+
+		n, err := io.Copy(w, r)
+		if err != nil {
+			w.CloseWithError(err)
+
+			return err
+		}
+
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		size = n
+
+		return nil
+	})
 	if err != nil {
 		fullPath := s.PathJoin(s.bucketName, name)
 
+		if isGCSPreconditionFailed(err) {
+			return 0, fmt.Errorf("%w: %s", ErrPreconditionFailed, fullPath)
+		}
+
 		return 0, fmt.Errorf("failed to write file %q: %w", fullPath, err)
 	}
 
 	return size, nil
 }
 
+// putConditions translates the precondition fields of opts into storage.Conditions.
+// The second return value is false when no precondition was requested.
+func putConditions(opts *PutOptions) (storage.Conditions, bool) {
+	var cond storage.Conditions
+	var set bool
+
+	if opts.IfNotExists {
+		cond.DoesNotExist = true
+		set = true
+	}
+
+	if opts.IfGenerationMatch != nil {
+		cond.GenerationMatch = *opts.IfGenerationMatch
+		set = true
+	}
+
+	return cond, set
+}
+
+// isGCSPreconditionFailed reports whether err is a GCS "412 Precondition Failed" response.
+func isGCSPreconditionFailed(err error) bool {
+	var gErr *googleapi.Error
+
+	return errors.As(err, &gErr) && gErr.Code == http.StatusPreconditionFailed
+}
+
+// putComposite uploads r in parallel chunks of opts.ChunkSize (up to
+// concurrency parts at a time) as temporary objects, then composes them into
+// a single object named name. This mirrors the "parallel composite upload"
+// pattern used by gsutil for large blobs.
+func (s *GCSStorage) putComposite(ctx context.Context, name string, r io.Reader, opts *PutOptions) (int64, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultMaxConcurrency
+	}
+
+	bucket := s.client.Bucket(s.bucketName)
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, concurrency)
+		total     int64
+		firstErr  error
+		partNames []string
+	)
+
+	for part := 0; ; part++ {
+		buf := make([]byte, chunkSize)
+
+		n, readErr := io.ReadFull(r, buf)
+		if n == 0 {
+			break
+		}
+
+		buf = buf[:n]
+		partName := fmt.Sprintf("%s.part%d", name, part)
+
+		mu.Lock()
+		partNames = append(partNames, partName)
+		total += int64(n)
+		mu.Unlock()
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(partName string, data []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			w := bucket.Object(partName).NewWriter(ctx)
+
+			if _, err := w.Write(data); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to write part %q: %w", partName, err)
+				}
+				mu.Unlock()
+				w.CloseWithError(err)
+
+				return
+			}
+
+			if err := w.Close(); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to close part %q: %w", partName, err)
+				}
+				mu.Unlock()
+			}
+		}(partName, buf)
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if readErr != nil {
+			wg.Wait()
+			s.deleteParts(ctx, partNames)
+
+			return 0, fmt.Errorf("failed to read data for %q: %w", name, readErr)
+		}
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		s.deleteParts(ctx, partNames)
+
+		return 0, firstErr
+	}
+
+	if len(partNames) == 0 {
+		return s.putEmpty(ctx, name, opts)
+	}
+
+	dst := bucket.Object(name)
+
+	tmpNames, err := s.composeHierarchical(ctx, bucket, dst, partNames, opts)
+
+	s.deleteParts(ctx, partNames)
+	s.deleteParts(ctx, tmpNames)
+
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		if isGCSPreconditionFailed(err) {
+			return 0, fmt.Errorf("%w: %s", ErrPreconditionFailed, fullPath)
+		}
+
+		return 0, fmt.Errorf("failed to compose object %q: %w", name, err)
+	}
+
+	return total, nil
+}
+
+// putEmpty writes a zero-byte object named name directly, applying
+// opts.ContentType, opts.Metadata, and any preconditions. It's used by
+// putComposite when the source stream contained no data, since Compose
+// rejects a call with zero source objects.
+func (s *GCSStorage) putEmpty(ctx context.Context, name string, opts *PutOptions) (int64, error) {
+	obj := s.client.Bucket(s.bucketName).Object(name)
+
+	if cond, ok := putConditions(opts); ok {
+		obj = obj.If(cond)
+	}
+
+	w := obj.NewWriter(ctx)
+
+	if opts.ContentType != "" {
+		w.ContentType = opts.ContentType
+	}
+
+	if len(opts.Metadata) > 0 {
+		w.Metadata = opts.Metadata
+	}
+
+	if err := w.Close(); err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		if isGCSPreconditionFailed(err) {
+			return 0, fmt.Errorf("%w: %s", ErrPreconditionFailed, fullPath)
+		}
+
+		return 0, fmt.Errorf("failed to write file %q: %w", fullPath, err)
+	}
+
+	return 0, nil
+}
+
+// gcsMaxComposeParts is the maximum number of source objects GCS accepts in a
+// single Compose request.
+const gcsMaxComposeParts = 32
+
+// composeHierarchical composes partNames into dst, applying opts.ContentType,
+// opts.Metadata, and any preconditions on the final Compose call. Compose
+// accepts at most gcsMaxComposeParts sources per call, so when there are more
+// parts than that, they're first combined into intermediate objects in groups
+// of gcsMaxComposeParts, repeating until at most gcsMaxComposeParts remain for
+// the final compose into dst. It returns the names of any intermediate
+// objects created, which the caller is responsible for deleting, even when it
+// also returns an error.
+func (s *GCSStorage) composeHierarchical(ctx context.Context, bucket *storage.BucketHandle, dst *storage.ObjectHandle, partNames []string, opts *PutOptions) ([]string, error) {
+	var tmpNames []string
+
+	level := 0
+	current := partNames
+
+	for len(current) > gcsMaxComposeParts {
+		var next []string
+
+		for i := 0; i < len(current); i += gcsMaxComposeParts {
+			end := i + gcsMaxComposeParts
+			if end > len(current) {
+				end = len(current)
+			}
+
+			var srcs []*storage.ObjectHandle
+			for _, partName := range current[i:end] {
+				srcs = append(srcs, bucket.Object(partName))
+			}
+
+			tmpName := fmt.Sprintf("%s.tmp%d-%d", dst.ObjectName(), level, i/gcsMaxComposeParts)
+
+			if _, err := bucket.Object(tmpName).ComposerFrom(srcs...).Run(ctx); err != nil {
+				return tmpNames, fmt.Errorf("failed to compose intermediate part %q: %w", tmpName, err)
+			}
+
+			tmpNames = append(tmpNames, tmpName)
+			next = append(next, tmpName)
+		}
+
+		current = next
+		level++
+	}
+
+	var srcs []*storage.ObjectHandle
+	for _, partName := range current {
+		srcs = append(srcs, bucket.Object(partName))
+	}
+
+	composer := dst.ComposerFrom(srcs...)
+
+	if opts.ContentType != "" {
+		composer.ContentType = opts.ContentType
+	}
+
+	if len(opts.Metadata) > 0 {
+		composer.Metadata = opts.Metadata
+	}
+
+	if cond, ok := putConditions(opts); ok {
+		composer.Conditions = cond
+	}
+
+	_, err := composer.Run(ctx)
+
+	return tmpNames, err
+}
+
+func (s *GCSStorage) deleteParts(ctx context.Context, partNames []string) {
+	bucket := s.client.Bucket(s.bucketName)
+
+	for _, partName := range partNames {
+		_ = bucket.Object(partName).Delete(ctx)
+	}
+}
+
+// Stat returns metadata for a single object without issuing a List.
+func (s *GCSStorage) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("name required")
+	}
+
+	var attrs *storage.ObjectAttrs
+
+	err = withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+		var err error
+
+		attrs, err = s.client.Bucket(s.bucketName).Object(name).Attrs(ctx)
+
+		return err
+	})
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		return nil, fmt.Errorf("failed to stat file %q: %w", fullPath, err)
+	}
+
+	return &ObjectInfo{
+		Name:       name,
+		Size:       attrs.Size,
+		UpdatedAt:  attrs.Updated,
+		Metadata:   attrs.Metadata,
+		ETag:       attrs.Etag,
+		Generation: attrs.Generation,
+	}, nil
+}
+
 // List returns a list of objects matching the specified prefix.
 func (s *GCSStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
 	err := s.checkClientAndBucket()
@@ -107,7 +507,15 @@ func (s *GCSStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, er
 	var objects []*ObjectInfo
 
 	for {
-		attrs, err := it.Next()
+		var attrs *storage.ObjectAttrs
+
+		err := withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+			var err error
+
+			attrs, err = it.Next()
+
+			return err
+		})
 		if err == iterator.Done {
 			break
 		}
@@ -119,7 +527,7 @@ func (s *GCSStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, er
 		}
 
 		objects = append(objects, &ObjectInfo{
-			Name:      s.PathJoin(prefix, attrs.Name),
+			Name:      attrs.Name,
 			Size:      attrs.Size,
 			UpdatedAt: attrs.Updated,
 			Metadata:  attrs.Metadata,
@@ -129,8 +537,74 @@ func (s *GCSStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, er
 	return objects, nil
 }
 
+// ListIter returns an iterator over objects matching the specified prefix,
+// fetching pages lazily instead of buffering the whole result in memory.
+func (s *GCSStorage) ListIter(ctx context.Context, prefix string, opts *ListOptions) ObjectIterator {
+	query := &storage.Query{Prefix: prefix}
+
+	if opts != nil {
+		switch {
+		case opts.Delimiter != "":
+			query.Delimiter = opts.Delimiter
+		case !opts.Recursive:
+			query.Delimiter = "/"
+		}
+	}
+
+	it := s.client.Bucket(s.bucketName).Objects(ctx, query)
+
+	if opts != nil && opts.PageSize > 0 {
+		it.PageInfo().MaxSize = opts.PageSize
+	}
+
+	return &gcsObjectIterator{ctx: ctx, s: s, it: it, prefix: prefix}
+}
+
+// gcsObjectIterator adapts a *storage.ObjectIterator to the ObjectIterator interface.
+type gcsObjectIterator struct {
+	ctx    context.Context
+	s      *GCSStorage
+	it     *storage.ObjectIterator
+	prefix string
+}
+
+// Next returns the next object, or io.EOF once iteration is complete.
+func (i *gcsObjectIterator) Next() (*ObjectInfo, error) {
+	var attrs *storage.ObjectAttrs
+
+	err := withRetry(i.ctx, i.s.retryPolicy, isRetryableGCSError, func() error {
+		var err error
+
+		attrs, err = i.it.Next()
+
+		return err
+	})
+	if err == iterator.Done {
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		fullPath := i.s.PathJoin(i.s.bucketName, i.prefix)
+
+		return nil, fmt.Errorf("failed to list %q: %w", fullPath, err)
+	}
+
+	// With a delimiter, GCS also yields "directory" prefixes that have only
+	// Prefix set; skip them so only objects are returned.
+	if attrs.Prefix != "" {
+		return i.Next()
+	}
+
+	return &ObjectInfo{
+		Name:      attrs.Name,
+		Size:      attrs.Size,
+		UpdatedAt: attrs.Updated,
+		Metadata:  attrs.Metadata,
+	}, nil
+}
+
 // Copy copies an object from the source path to the destination path.
-func (s *GCSStorage) Copy(ctx context.Context, src string, dst string) error {
+func (s *GCSStorage) Copy(ctx context.Context, src string, dst string, opts *CopyOptions) error {
 	err := s.checkClientAndBucket()
 	if err != nil {
 		return fmt.Errorf("invalid storage client: %w", err)
@@ -144,18 +618,44 @@ func (s *GCSStorage) Copy(ctx context.Context, src string, dst string) error {
 		return fmt.Errorf("dst required")
 	}
 
+	if opts != nil && !opts.OverwriteIfExists {
+		if err := s.checkNotExists(ctx, dst); err != nil {
+			return err
+		}
+	}
+
 	srcObj := s.client.Bucket(s.bucketName).Object(src)
 	dstObj := s.client.Bucket(s.bucketName).Object(dst)
 
-	if _, err := dstObj.CopierFrom(srcObj).Run(ctx); err != nil {
+	err = withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+		_, err := dstObj.CopierFrom(srcObj).Run(ctx)
+
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("failed to copy object from %q to %q: %w", src, dst, err)
 	}
 
 	return nil
 }
 
+// checkNotExists returns ErrPreconditionFailed if name already exists, nil if
+// it doesn't, or any other error encountered while checking.
+func (s *GCSStorage) checkNotExists(ctx context.Context, name string) error {
+	_, err := s.Stat(ctx, name)
+
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: destination %q already exists", ErrPreconditionFailed, name)
+	case errors.Is(err, storage.ErrObjectNotExist):
+		return nil
+	default:
+		return err
+	}
+}
+
 // Move moves (renames) an object from the source path to the destination path.
-func (s *GCSStorage) Move(ctx context.Context, src string, dst string) error {
+func (s *GCSStorage) Move(ctx context.Context, src string, dst string, opts *CopyOptions) error {
 	err := s.checkClientAndBucket()
 	if err != nil {
 		return fmt.Errorf("invalid storage client: %w", err)
@@ -169,7 +669,7 @@ func (s *GCSStorage) Move(ctx context.Context, src string, dst string) error {
 		return fmt.Errorf("dst required")
 	}
 
-	err = s.Copy(ctx, src, dst)
+	err = s.Copy(ctx, src, dst, opts)
 	if err != nil {
 		return fmt.Errorf("failed to copy file %q to %q: %w", src, dst, err)
 	}
@@ -193,7 +693,9 @@ func (s *GCSStorage) Delete(ctx context.Context, name string) error {
 		return fmt.Errorf("name required")
 	}
 
-	err = s.client.Bucket(s.bucketName).Object(name).Delete(ctx)
+	err = withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+		return s.client.Bucket(s.bucketName).Object(name).Delete(ctx)
+	})
 	if err != nil {
 		fullPath := s.PathJoin(s.bucketName, name)
 
@@ -222,7 +724,15 @@ func (s *GCSStorage) DeleteAll(ctx context.Context, prefix string) error {
 	it := s.client.Bucket(s.bucketName).Objects(ctx, query)
 
 	for {
-		attrs, err := it.Next()
+		var attrs *storage.ObjectAttrs
+
+		err := withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+			var err error
+
+			attrs, err = it.Next()
+
+			return err
+		})
 		if err == iterator.Done {
 			break
 		}
@@ -233,7 +743,9 @@ func (s *GCSStorage) DeleteAll(ctx context.Context, prefix string) error {
 			return fmt.Errorf("failed to list %q: %w", fullPath, err)
 		}
 
-		err = s.client.Bucket(s.bucketName).Object(attrs.Name).Delete(ctx)
+		err = withRetry(ctx, s.retryPolicy, isRetryableGCSError, func() error {
+			return s.client.Bucket(s.bucketName).Object(attrs.Name).Delete(ctx)
+		})
 		if err != nil {
 			return fmt.Errorf("failed to delete %s: %w", attrs.Name, err)
 		}
@@ -247,6 +759,62 @@ func (s *GCSStorage) PathJoin(elem ...string) string {
 	return path.Join(elem...)
 }
 
+// SignedURL returns a V4 signed URL for the object with the specified name.
+func (s *GCSStorage) SignedURL(ctx context.Context, name string, opts *SignURLOptions) (string, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return "", fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("name required")
+	}
+
+	expires := DefaultSignedURLExpiry
+
+	signOpts := &storage.SignedURLOptions{
+		Scheme: storage.SigningSchemeV4,
+		Method: http.MethodGet,
+	}
+
+	if opts != nil {
+		if opts.Method != "" {
+			signOpts.Method = opts.Method
+		}
+
+		if opts.Expires > 0 {
+			expires = opts.Expires
+		}
+
+		if opts.ContentType != "" {
+			signOpts.ContentType = opts.ContentType
+		}
+
+		if len(opts.ResponseHeaders) > 0 {
+			// GCS overrides response headers through "response-*" query
+			// parameters; signOpts.Headers would instead be request headers
+			// the client must send.
+			params := neturl.Values{}
+			for k, v := range opts.ResponseHeaders {
+				params.Set("response-"+strings.ToLower(k), v)
+			}
+
+			signOpts.QueryParameters = params
+		}
+	}
+
+	signOpts.Expires = time.Now().Add(expires)
+
+	url, err := s.client.Bucket(s.bucketName).SignedURL(name, signOpts)
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		return "", fmt.Errorf("failed to sign url for %q: %w", fullPath, err)
+	}
+
+	return url, nil
+}
+
 func (s *GCSStorage) checkClientAndBucket() error {
 	if s.client == nil {
 		return fmt.Errorf("storage client required")
@@ -1,14 +1,19 @@
 package harestorage
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 )
 
-var _ Storage = (*LocalStorage)(nil)
+var (
+	_ Storage   = (*LocalStorage)(nil)
+	_ URLSigner = (*LocalStorage)(nil)
+)
 
 // LocalStorage implements the Storage interface for the local filesystem.
 type LocalStorage struct {
@@ -61,6 +66,10 @@ func (s *LocalStorage) Put(ctx context.Context, name string, r io.Reader, opts *
 		return 0, fmt.Errorf("name required")
 	}
 
+	if opts != nil && opts.IfGenerationMatch != nil {
+		return 0, fmt.Errorf("IfGenerationMatch: %w", ErrUnsupported)
+	}
+
 	fullPath := s.PathJoin(s.rootDir, name)
 	dir := filepath.Dir(fullPath)
 
@@ -69,69 +78,204 @@ func (s *LocalStorage) Put(ctx context.Context, name string, r io.Reader, opts *
 		return 0, fmt.Errorf("failed to create directory %q: %w", dir, err)
 	}
 
-	f, err := os.Create(fullPath)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if opts != nil && opts.IfNotExists {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	f, err := os.OpenFile(fullPath, flags, 0644)
 	if err != nil {
+		if opts != nil && opts.IfNotExists && os.IsExist(err) {
+			return 0, fmt.Errorf("%w: %s", ErrPreconditionFailed, fullPath)
+		}
+
 		return 0, fmt.Errorf("failed to create file %q: %w", fullPath, err)
 	}
 
 	defer f.Close()
 
-	size, err := io.Copy(f, r)
+	var w io.Writer = f
+
+	var bw *bufio.Writer
+	if opts != nil && opts.ChunkSize > 0 {
+		bw = bufio.NewWriterSize(f, int(opts.ChunkSize))
+		w = bw
+	}
+
+	size, err := io.Copy(w, r)
 	if err != nil {
 		return 0, fmt.Errorf("failed to write file %q: %w", fullPath, err)
 	}
 
+	if bw != nil {
+		if err := bw.Flush(); err != nil {
+			return 0, fmt.Errorf("failed to flush file %q: %w", fullPath, err)
+		}
+	}
+
 	return size, nil
 }
 
-// List returns a list of objects matching the specified prefix.
-func (s *LocalStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+// Stat returns metadata for a single object without issuing a List.
+func (s *LocalStorage) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
 	err := s.checkRootDir()
 	if err != nil {
 		return nil, fmt.Errorf("invalid root path: %w", err)
 	}
 
-	if prefix == "" {
-		return nil, fmt.Errorf("prefix required")
+	if name == "" {
+		return nil, fmt.Errorf("name required")
 	}
 
-	fullPath := s.PathJoin(s.rootDir, prefix)
+	fullPath := s.PathJoin(s.rootDir, name)
 
-	files, err := os.ReadDir(fullPath)
+	info, err := os.Stat(fullPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []*ObjectInfo{}, nil
+		return nil, fmt.Errorf("failed to stat file %q: %w", fullPath, err)
+	}
+
+	return &ObjectInfo{
+		Name:      name,
+		Size:      info.Size(),
+		UpdatedAt: info.ModTime(),
+		Metadata:  map[string]string{},
+	}, nil
+}
+
+// List returns a list of objects matching the specified prefix.
+//
+// To match the recursive listing behavior of flat-namespace backends such as
+// GCSStorage, this lists objects in all subdirectories of prefix, not just its
+// direct children. Use ListIter with ListOptions{Recursive: false} for a
+// single-level listing.
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	it := s.ListIter(ctx, prefix, &ListOptions{Recursive: true})
+
+	objects := []*ObjectInfo{}
+
+	for {
+		obj, err := it.Next()
+		if err == io.EOF {
+			break
 		}
 
-		return nil, fmt.Errorf("failed to list files %q: %w", fullPath, err)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, obj)
 	}
 
-	objects := make([]*ObjectInfo, 0, len(files))
+	return objects, nil
+}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
+// ListIter returns an iterator over objects matching the specified prefix,
+// walking the filesystem lazily instead of buffering the whole result in
+// memory. The walk runs on a background goroutine that sends each entry over
+// an unbuffered channel; if the caller stops calling Next before it returns
+// io.EOF or an error, that goroutine leaks unless ctx is cancelled.
+func (s *LocalStorage) ListIter(ctx context.Context, prefix string, opts *ListOptions) ObjectIterator {
+	it := &localObjectIterator{
+		objects: make(chan *ObjectInfo),
+		errCh:   make(chan error, 1),
+	}
+
+	recursive := opts != nil && opts.Recursive
 
-		info, err := file.Info()
+	go func() {
+		defer close(it.objects)
+
+		err := s.checkRootDir()
 		if err != nil {
-			// ファイルが消えている可能性などは無視
-			continue
+			it.errCh <- fmt.Errorf("invalid root path: %w", err)
+
+			return
+		}
+
+		if prefix == "" {
+			it.errCh <- fmt.Errorf("prefix required")
+
+			return
 		}
 
-		objects = append(objects, &ObjectInfo{
-			Name:      filepath.ToSlash(s.PathJoin(prefix, file.Name())),
-			Size:      info.Size(),
-			UpdatedAt: info.ModTime(),
-			Metadata:  map[string]string{},
+		fullPath := s.PathJoin(s.rootDir, prefix)
+
+		walkErr := filepath.WalkDir(fullPath, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return err
+			}
+
+			if d.IsDir() {
+				if !recursive && p != fullPath {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				// ファイルが消えている可能性などは無視
+				return nil
+			}
+
+			rel, err := filepath.Rel(s.rootDir, p)
+			if err != nil {
+				return err
+			}
+
+			obj := &ObjectInfo{
+				Name:      filepath.ToSlash(rel),
+				Size:      info.Size(),
+				UpdatedAt: info.ModTime(),
+				Metadata:  map[string]string{},
+			}
+
+			select {
+			case it.objects <- obj:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			return nil
 		})
+
+		if walkErr != nil && !os.IsNotExist(walkErr) {
+			it.errCh <- fmt.Errorf("failed to list files %q: %w", fullPath, walkErr)
+		}
+	}()
+
+	return it
+}
+
+// localObjectIterator adapts a lazy filepath.WalkDir traversal to the
+// ObjectIterator interface.
+type localObjectIterator struct {
+	objects chan *ObjectInfo
+	errCh   chan error
+}
+
+// Next returns the next object, or io.EOF once iteration is complete.
+func (it *localObjectIterator) Next() (*ObjectInfo, error) {
+	obj, ok := <-it.objects
+	if !ok {
+		select {
+		case err := <-it.errCh:
+			return nil, err
+		default:
+			return nil, io.EOF
+		}
 	}
 
-	return objects, nil
+	return obj, nil
 }
 
 // Copy copies an object from the source path to the destination path.
-func (s *LocalStorage) Copy(ctx context.Context, src string, dst string) error {
+func (s *LocalStorage) Copy(ctx context.Context, src string, dst string, opts *CopyOptions) error {
 	err := s.checkRootDir()
 	if err != nil {
 		return fmt.Errorf("invalid storage client: %w", err)
@@ -145,6 +289,12 @@ func (s *LocalStorage) Copy(ctx context.Context, src string, dst string) error {
 		return fmt.Errorf("dst required")
 	}
 
+	if opts != nil && !opts.OverwriteIfExists {
+		if err := s.checkNotExists(ctx, dst); err != nil {
+			return err
+		}
+	}
+
 	srcPath := filepath.Join(s.rootDir, src)
 	dstPath := filepath.Join(s.rootDir, dst)
 
@@ -175,8 +325,23 @@ func (s *LocalStorage) Copy(ctx context.Context, src string, dst string) error {
 	return nil
 }
 
+// checkNotExists returns ErrPreconditionFailed if name already exists, nil if
+// it doesn't, or any other error encountered while checking.
+func (s *LocalStorage) checkNotExists(ctx context.Context, name string) error {
+	_, err := s.Stat(ctx, name)
+
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: destination %q already exists", ErrPreconditionFailed, name)
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	default:
+		return err
+	}
+}
+
 // Move moves (renames) an object from the source path to the destination path.
-func (s *LocalStorage) Move(ctx context.Context, src string, dst string) error {
+func (s *LocalStorage) Move(ctx context.Context, src string, dst string, opts *CopyOptions) error {
 	err := s.checkRootDir()
 	if err != nil {
 		return fmt.Errorf("invalid storage client: %w", err)
@@ -190,6 +355,12 @@ func (s *LocalStorage) Move(ctx context.Context, src string, dst string) error {
 		return fmt.Errorf("dst required")
 	}
 
+	if opts != nil && !opts.OverwriteIfExists {
+		if err := s.checkNotExists(ctx, dst); err != nil {
+			return err
+		}
+	}
+
 	srcPath := filepath.Join(s.rootDir, src)
 	dstPath := filepath.Join(s.rootDir, dst)
 
@@ -204,7 +375,7 @@ func (s *LocalStorage) Move(ctx context.Context, src string, dst string) error {
 		return nil
 	}
 
-	err = s.Copy(ctx, src, dst)
+	err = s.Copy(ctx, src, dst, opts)
 	if err != nil {
 		return fmt.Errorf("failed to copy file %q to %q: %w", src, dst, err)
 	}
@@ -264,6 +435,12 @@ func (s *LocalStorage) PathJoin(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+// SignedURL is not supported by LocalStorage since there is no HTTP endpoint
+// serving its files; it always returns ErrUnsupported.
+func (s *LocalStorage) SignedURL(ctx context.Context, name string, opts *SignURLOptions) (string, error) {
+	return "", ErrUnsupported
+}
+
 func (s *LocalStorage) checkRootDir() error {
 	if s.rootDir == "" {
 		return fmt.Errorf("root dir required")
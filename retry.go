@@ -0,0 +1,95 @@
+package harestorage
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures retry behavior for network operations against a
+// storage backend's backing service.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values less than or equal to zero fall back to DefaultRetryPolicy.MaxAttempts.
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff between retries. Jitter is applied after
+	// the cap, so a single wait can exceed MaxBackoff by up to the Jitter
+	// fraction.
+	MaxBackoff time.Duration
+	// Multiplier scales the backoff after each failed attempt.
+	Multiplier float64
+	// Jitter is the fraction (0-1) of the computed backoff randomly added or
+	// subtracted, to avoid many clients retrying in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by NewGCSStorage and NewS3Storage when the
+// caller does not supply one via WithRetryPolicy or WithS3RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+
+	if p.Multiplier <= 0 {
+		p.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+
+	return p
+}
+
+// withRetry calls fn, retrying with exponential backoff while isRetryable(err)
+// reports true, up to policy.MaxAttempts attempts. It stops early if ctx is
+// canceled while waiting between attempts.
+func withRetry(ctx context.Context, policy RetryPolicy, isRetryable func(error) bool, fn func() error) error {
+	policy = policy.withDefaults()
+
+	backoff := policy.InitialBackoff
+
+	var err error
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt == policy.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := backoff
+		if policy.Jitter > 0 {
+			delta := float64(wait) * policy.Jitter
+			wait += time.Duration((rand.Float64()*2 - 1) * delta)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		backoff = time.Duration(math.Min(float64(policy.MaxBackoff), float64(backoff)*policy.Multiplier))
+	}
+
+	return err
+}
@@ -0,0 +1,667 @@
+package harestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// deleteBatchSize is the maximum number of objects DeleteObjects accepts per request.
+const deleteBatchSize = 1000
+
+var (
+	_ Storage   = (*S3Storage)(nil)
+	_ URLSigner = (*S3Storage)(nil)
+)
+
+// S3Storage implements the Storage interface for Amazon S3 (and S3-compatible endpoints such as MinIO).
+type S3Storage struct {
+	client      *s3.Client
+	bucketName  string
+	name        string
+	retryPolicy RetryPolicy
+}
+
+// S3Option configures an S3Storage constructed by NewS3Storage.
+type S3Option func(*S3Storage)
+
+// WithS3RetryPolicy overrides DefaultRetryPolicy for an S3Storage's network operations.
+func WithS3RetryPolicy(policy RetryPolicy) S3Option {
+	return func(s *S3Storage) {
+		s.retryPolicy = policy
+	}
+}
+
+// NewS3Storage creates a new S3Storage instance with the specified client, bucket name, and storage name.
+func NewS3Storage(client *s3.Client, bucketName string, name string, opts ...S3Option) *S3Storage {
+	s := &S3Storage{
+		client:      client,
+		bucketName:  bucketName,
+		name:        name,
+		retryPolicy: DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Name returns the name (identifier) of this storage.
+func (s *S3Storage) Name() string {
+	return s.name
+}
+
+// Get returns an io.ReadCloser to read the object with the specified name.
+func (s *S3Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return nil, fmt.Errorf("storage client: %w", err)
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("name required")
+	}
+
+	var body io.ReadCloser
+
+	err = withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+		out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(name),
+		})
+		if err != nil {
+			return err
+		}
+
+		body = out.Body
+
+		return nil
+	})
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		return nil, fmt.Errorf("failed to open file %q: %w", fullPath, err)
+	}
+
+	return body, nil
+}
+
+// isRetryableS3Error reports whether err is a transient S3 error worth retrying.
+func isRetryableS3Error(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "RequestTimeout", "SlowDown", "ServiceUnavailable", "InternalError",
+			"RequestThrottled", "ThrottlingException", "TooManyRequestsException":
+			return true
+		}
+
+		return false
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Put saves data to the storage with the specified name.
+func (s *S3Storage) Put(ctx context.Context, name string, r io.Reader, opts *PutOptions) (int64, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return 0, fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if name == "" {
+		return 0, fmt.Errorf("name required")
+	}
+
+	if opts != nil && opts.IfGenerationMatch != nil {
+		return 0, fmt.Errorf("IfGenerationMatch: %w", ErrUnsupported)
+	}
+
+	seeker, seekable := r.(io.ReadSeeker)
+
+	policy := s.retryPolicy
+	if !seekable && (opts == nil || !opts.Retryable) {
+		policy.MaxAttempts = 1
+	}
+
+	if opts != nil && opts.IfNotExists {
+		// A retry can't tell a lost ack from a real conflict: if the first
+		// attempt actually succeeded, retrying a conditional write would hit
+		// the object it just created and return a spurious
+		// ErrPreconditionFailed for a Put that succeeded.
+		policy.MaxAttempts = 1
+	}
+
+	uploader := manager.NewUploader(s.client, func(u *manager.Uploader) {
+		if opts != nil && opts.ChunkSize > 0 {
+			u.PartSize = opts.ChunkSize
+		}
+
+		if opts != nil && opts.MaxConcurrency > 0 {
+			u.Concurrency = opts.MaxConcurrency
+		}
+	})
+
+	var size int64
+
+	err = withRetry(ctx, policy, isRetryableS3Error, func() error {
+		if seekable {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+
+		counted := &countingReader{r: r}
+
+		input := &s3.PutObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(name),
+			Body:   counted,
+		}
+
+		if opts != nil {
+			if opts.ContentType != "" {
+				input.ContentType = aws.String(opts.ContentType)
+			}
+
+			if len(opts.Metadata) > 0 {
+				input.Metadata = opts.Metadata
+			}
+
+			if opts.IfNotExists {
+				input.IfNoneMatch = aws.String("*")
+			}
+		}
+
+		if _, err := uploader.Upload(ctx, input); err != nil {
+			return err
+		}
+
+		size = counted.n
+
+		return nil
+	})
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		if isS3PreconditionFailed(err) {
+			return 0, fmt.Errorf("%w: %s", ErrPreconditionFailed, fullPath)
+		}
+
+		return 0, fmt.Errorf("failed to write file %q: %w", fullPath, err)
+	}
+
+	return size, nil
+}
+
+// isS3PreconditionFailed reports whether err is an S3 "PreconditionFailed" response,
+// as returned when an IfNoneMatch condition on PutObject is not met.
+func isS3PreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed"
+}
+
+// countingReader wraps an io.Reader and tracks the number of bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// Stat returns metadata for a single object without issuing a List.
+func (s *S3Storage) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("name required")
+	}
+
+	var out *s3.HeadObjectOutput
+
+	err = withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+		var err error
+
+		out, err = s.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(name),
+		})
+
+		return err
+	})
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		return nil, fmt.Errorf("failed to stat file %q: %w", fullPath, err)
+	}
+
+	return &ObjectInfo{
+		Name:      name,
+		Size:      aws.ToInt64(out.ContentLength),
+		UpdatedAt: aws.ToTime(out.LastModified),
+		Metadata:  out.Metadata,
+		ETag:      aws.ToString(out.ETag),
+	}, nil
+}
+
+// List returns a list of objects matching the specified prefix.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if prefix == "" {
+		return nil, fmt.Errorf("prefix required")
+	}
+
+	var objects []*ObjectInfo
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		var page *s3.ListObjectsV2Output
+
+		err := withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+			var err error
+
+			page, err = paginator.NextPage(ctx)
+
+			return err
+		})
+		if err != nil {
+			fullPath := s.PathJoin(s.bucketName, prefix)
+
+			return nil, fmt.Errorf("failed to list %q: %w", fullPath, err)
+		}
+
+		for _, obj := range page.Contents {
+			objects = append(objects, &ObjectInfo{
+				Name:      aws.ToString(obj.Key),
+				Size:      aws.ToInt64(obj.Size),
+				UpdatedAt: aws.ToTime(obj.LastModified),
+				Metadata:  map[string]string{},
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+// ListIter returns an iterator over objects matching the specified prefix,
+// fetching pages lazily instead of buffering the whole result in memory.
+func (s *S3Storage) ListIter(ctx context.Context, prefix string, opts *ListOptions) ObjectIterator {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	}
+
+	if opts != nil {
+		switch {
+		case opts.Delimiter != "":
+			input.Delimiter = aws.String(opts.Delimiter)
+		case !opts.Recursive:
+			input.Delimiter = aws.String("/")
+		}
+
+		if opts.PageSize > 0 {
+			input.MaxKeys = aws.Int32(int32(opts.PageSize))
+		}
+	}
+
+	return &s3ObjectIterator{
+		s:         s,
+		prefix:    prefix,
+		ctx:       ctx,
+		paginator: s3.NewListObjectsV2Paginator(s.client, input),
+	}
+}
+
+// s3ObjectIterator adapts a *s3.ListObjectsV2Paginator to the ObjectIterator interface.
+type s3ObjectIterator struct {
+	s         *S3Storage
+	prefix    string
+	paginator *s3.ListObjectsV2Paginator
+	page      []types.Object
+	pageIdx   int
+	ctx       context.Context
+}
+
+// Next returns the next object, or io.EOF once iteration is complete.
+func (i *s3ObjectIterator) Next() (*ObjectInfo, error) {
+	for i.pageIdx >= len(i.page) {
+		if !i.paginator.HasMorePages() {
+			return nil, io.EOF
+		}
+
+		var page *s3.ListObjectsV2Output
+
+		err := withRetry(i.ctx, i.s.retryPolicy, isRetryableS3Error, func() error {
+			var err error
+
+			page, err = i.paginator.NextPage(i.ctx)
+
+			return err
+		})
+		if err != nil {
+			fullPath := i.s.PathJoin(i.s.bucketName, i.prefix)
+
+			return nil, fmt.Errorf("failed to list %q: %w", fullPath, err)
+		}
+
+		i.page = page.Contents
+		i.pageIdx = 0
+	}
+
+	obj := i.page[i.pageIdx]
+	i.pageIdx++
+
+	return &ObjectInfo{
+		Name:      aws.ToString(obj.Key),
+		Size:      aws.ToInt64(obj.Size),
+		UpdatedAt: aws.ToTime(obj.LastModified),
+		Metadata:  map[string]string{},
+	}, nil
+}
+
+// encodeCopySourceKey percent-encodes a key for use in the CopySource field of
+// a CopyObject request. The SDK does not URL-encode CopySource on our behalf,
+// so keys containing spaces or other reserved characters must be escaped here
+// to avoid a malformed copy source. Slashes are preserved rather than escaped
+// since they separate path segments within the key.
+func encodeCopySourceKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = strings.ReplaceAll(url.QueryEscape(seg), "+", "%20")
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// Copy copies an object from the source path to the destination path.
+func (s *S3Storage) Copy(ctx context.Context, src string, dst string, opts *CopyOptions) error {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if src == "" {
+		return fmt.Errorf("src required")
+	}
+
+	if dst == "" {
+		return fmt.Errorf("dst required")
+	}
+
+	if opts != nil && !opts.OverwriteIfExists {
+		if err := s.checkNotExists(ctx, dst); err != nil {
+			return err
+		}
+	}
+
+	source := path.Join(s.bucketName, encodeCopySourceKey(src))
+
+	err = withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+		_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String(s.bucketName),
+			Key:        aws.String(dst),
+			CopySource: aws.String(source),
+		})
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy object from %q to %q: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+// checkNotExists returns ErrPreconditionFailed if name already exists, nil if
+// it doesn't, or any other error encountered while checking.
+func (s *S3Storage) checkNotExists(ctx context.Context, name string) error {
+	_, err := s.Stat(ctx, name)
+
+	var notFound *types.NotFound
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: destination %q already exists", ErrPreconditionFailed, name)
+	case errors.As(err, &notFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// Move moves (renames) an object from the source path to the destination path.
+func (s *S3Storage) Move(ctx context.Context, src string, dst string, opts *CopyOptions) error {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if src == "" {
+		return fmt.Errorf("src required")
+	}
+
+	if dst == "" {
+		return fmt.Errorf("dst required")
+	}
+
+	err = s.Copy(ctx, src, dst, opts)
+	if err != nil {
+		return fmt.Errorf("failed to copy file %q to %q: %w", src, dst, err)
+	}
+
+	err = s.Delete(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to delete file %q: %w", src, err)
+	}
+
+	return nil
+}
+
+// Delete deletes the object with the specified name.
+func (s *S3Storage) Delete(ctx context.Context, name string) error {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if name == "" {
+		return fmt.Errorf("name required")
+	}
+
+	err = withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+		_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(name),
+		})
+
+		return err
+	})
+	if err != nil {
+		fullPath := s.PathJoin(s.bucketName, name)
+
+		return fmt.Errorf("failed to delete file %q: %w", fullPath, err)
+	}
+
+	return nil
+}
+
+// DeleteAll deletes all objects matching the specified prefix.
+func (s *S3Storage) DeleteAll(ctx context.Context, prefix string) error {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if prefix == "" {
+		return fmt.Errorf("prefix required")
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		var page *s3.ListObjectsV2Output
+
+		err := withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+			var err error
+
+			page, err = paginator.NextPage(ctx)
+
+			return err
+		})
+		if err != nil {
+			fullPath := s.PathJoin(s.bucketName, prefix)
+
+			return fmt.Errorf("failed to list %q: %w", fullPath, err)
+		}
+
+		for i := 0; i < len(page.Contents); i += deleteBatchSize {
+			end := i + deleteBatchSize
+			if end > len(page.Contents) {
+				end = len(page.Contents)
+			}
+
+			objIds := make([]types.ObjectIdentifier, 0, end-i)
+			for _, obj := range page.Contents[i:end] {
+				objIds = append(objIds, types.ObjectIdentifier{Key: obj.Key})
+			}
+
+			err := withRetry(ctx, s.retryPolicy, isRetryableS3Error, func() error {
+				_, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+					Bucket: aws.String(s.bucketName),
+					Delete: &types.Delete{Objects: objIds},
+				})
+
+				return err
+			})
+			if err != nil {
+				fullPath := s.PathJoin(s.bucketName, prefix)
+
+				return fmt.Errorf("failed to delete %q: %w", fullPath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PathJoin joins multiple path elements according to the storage's format.
+func (s *S3Storage) PathJoin(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// SignedURL returns a presigned URL for the object with the specified name.
+// Only the "GET" and "PUT" methods are supported; any other method returns
+// ErrUnsupported. opts.ResponseHeaders are not applied to the URL.
+func (s *S3Storage) SignedURL(ctx context.Context, name string, opts *SignURLOptions) (string, error) {
+	err := s.checkClientAndBucket()
+	if err != nil {
+		return "", fmt.Errorf("invalid storage client: %w", err)
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("name required")
+	}
+
+	method := http.MethodGet
+	expires := DefaultSignedURLExpiry
+
+	if opts != nil {
+		if opts.Method != "" {
+			method = opts.Method
+		}
+
+		if opts.Expires > 0 {
+			expires = opts.Expires
+		}
+	}
+
+	presignClient := s3.NewPresignClient(s.client)
+
+	var url string
+
+	switch method {
+	case http.MethodPut:
+		input := &s3.PutObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(name),
+		}
+
+		if opts != nil && opts.ContentType != "" {
+			input.ContentType = aws.String(opts.ContentType)
+		}
+
+		req, err := presignClient.PresignPutObject(ctx, input, s3.WithPresignExpires(expires))
+		if err != nil {
+			fullPath := s.PathJoin(s.bucketName, name)
+
+			return "", fmt.Errorf("failed to sign url for %q: %w", fullPath, err)
+		}
+
+		url = req.URL
+	case http.MethodGet:
+		input := &s3.GetObjectInput{
+			Bucket: aws.String(s.bucketName),
+			Key:    aws.String(name),
+		}
+
+		req, err := presignClient.PresignGetObject(ctx, input, s3.WithPresignExpires(expires))
+		if err != nil {
+			fullPath := s.PathJoin(s.bucketName, name)
+
+			return "", fmt.Errorf("failed to sign url for %q: %w", fullPath, err)
+		}
+
+		url = req.URL
+	default:
+		return "", fmt.Errorf("method %q: %w", method, ErrUnsupported)
+	}
+
+	return url, nil
+}
+
+func (s *S3Storage) checkClientAndBucket() error {
+	if s.client == nil {
+		return fmt.Errorf("storage client required")
+	}
+
+	if s.bucketName == "" {
+		return fmt.Errorf("bucketName required")
+	}
+
+	return nil
+}
@@ -2,10 +2,22 @@ package harestorage
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 )
 
+// ErrUnsupported indicates that a storage backend does not support the
+// requested operation.
+var ErrUnsupported = errors.New("harestorage: operation not supported by this storage backend")
+
+// ErrPreconditionFailed indicates that a conditional Put (IfGenerationMatch or
+// IfNotExists) was rejected because the object's current state didn't match
+// the condition, or that a Copy, Move, or StorageSet.CopyBetween with a
+// non-nil CopyOptions whose OverwriteIfExists is false found the destination
+// already existing.
+var ErrPreconditionFailed = errors.New("harestorage: precondition failed")
+
 // Storage defines the interface for a set of operations on object storage.
 type Storage interface {
 	// Name returns the name (identifier) of this storage.
@@ -16,10 +28,15 @@ type Storage interface {
 	Put(ctx context.Context, name string, r io.Reader, opts *PutOptions) (int64, error)
 	// List returns a list of objects matching the specified prefix.
 	List(ctx context.Context, prefix string) ([]*ObjectInfo, error)
+	// Stat returns metadata for a single object without issuing a List.
+	Stat(ctx context.Context, name string) (*ObjectInfo, error)
+	// ListIter returns an iterator over objects matching the specified prefix,
+	// fetching pages lazily instead of buffering the whole result in memory.
+	ListIter(ctx context.Context, prefix string, opts *ListOptions) ObjectIterator
 	// Copy copies an object from the source path to the destination path.
-	Copy(ctx context.Context, src string, dst string) error
+	Copy(ctx context.Context, src string, dst string, opts *CopyOptions) error
 	// Move moves (renames) an object from the source path to the destination path.
-	Move(ctx context.Context, src string, dst string) error
+	Move(ctx context.Context, src string, dst string, opts *CopyOptions) error
 	// Delete deletes the object with the specified name.
 	Delete(ctx context.Context, name string) error
 	// DeleteAll deletes all objects matching the specified prefix.
@@ -34,6 +51,111 @@ type PutOptions struct {
 	ContentType string
 	// Metadata is arbitrary metadata associated with the object.
 	Metadata map[string]string
+	// ChunkSize is the size in bytes of each chunk used for a resumable/multipart upload.
+	// Zero means the backend's default chunk size is used.
+	ChunkSize int64
+	// MaxConcurrency is the maximum number of chunks uploaded in parallel.
+	// On GCSStorage, values less than or equal to 1 disable concurrent uploads
+	// and instead use a single-stream upload sized by ChunkSize. S3Storage
+	// passes values greater than 0 to its multipart uploader as the part
+	// concurrency and keeps the uploader's default concurrency when it is 0.
+	MaxConcurrency int
+	// IfGenerationMatch makes Put conditional on the object's current
+	// generation/version matching this value. nil means no condition. A
+	// conditional Put is never retried, regardless of Retryable or whether r
+	// is an io.ReadSeeker: a retry can't distinguish a lost ack from a real
+	// conflict, so retrying could return ErrPreconditionFailed for a write
+	// that actually succeeded.
+	IfGenerationMatch *int64
+	// IfNotExists makes Put conditional on the object not already existing.
+	// If it does, Put returns ErrPreconditionFailed. As with
+	// IfGenerationMatch, a conditional Put is never retried.
+	IfNotExists bool
+	// Retryable allows Put to be retried on a transient error even though r is
+	// not an io.ReadSeeker. The caller is responsible for ensuring r can be
+	// safely re-read (or re-sent) from the beginning on retry. GCSStorage and
+	// S3Storage always retry Put when r implements io.ReadSeeker, regardless
+	// of this field. GCSStorage's parallel composite upload (MaxConcurrency
+	// greater than 1) is not retried, and LocalStorage never retries.
+	Retryable bool
+}
+
+// Default chunk size and concurrency for GCSStorage's parallel composite upload
+// when PutOptions does not specify them. That upload only runs when
+// MaxConcurrency is greater than 1, so DefaultMaxConcurrency is not applied
+// to a Put that leaves MaxConcurrency unset. Single-stream GCS uploads and
+// S3Storage use their client library's defaults instead.
+const (
+	DefaultChunkSize      = 16 * 1024 * 1024
+	DefaultMaxConcurrency = 50
+)
+
+// CopyOptions holds optional settings for Copy, Move, and StorageSet.CopyBetween.
+type CopyOptions struct {
+	// OverwriteIfExists allows the operation to proceed even if the
+	// destination already exists. When false (the zero value), passing a
+	// non-nil CopyOptions makes the operation fail instead of silently
+	// overwriting the destination. A nil *CopyOptions always overwrites, to
+	// preserve the original unconditional behavior of Copy/Move.
+	OverwriteIfExists bool
+	// PreserveMetadata carries the source object's metadata over to the
+	// destination. Same-backend copies already preserve metadata via the
+	// backend's server-side copy; this mainly matters for the cross-backend
+	// Get->Put fallback used by StorageSet.CopyBetween.
+	PreserveMetadata bool
+}
+
+// ObjectIterator iterates over the objects returned by ListIter.
+//
+// Some backends (such as LocalStorage) walk the result set on a background
+// goroutine that feeds Next through a channel. Callers that stop calling Next
+// before it returns io.EOF or an error must cancel the ctx passed to ListIter
+// so that goroutine can exit; otherwise it leaks, blocked sending to a
+// consumer that will never read again.
+type ObjectIterator interface {
+	// Next returns the next object, or io.EOF once iteration is complete.
+	Next() (*ObjectInfo, error)
+}
+
+// ListOptions holds optional settings for a ListIter call.
+type ListOptions struct {
+	// Recursive lists objects below all levels of the prefix. When false, only
+	// the direct children of prefix are returned.
+	Recursive bool
+	// PageSize hints the number of objects fetched per underlying page request.
+	// Zero means the backend's default page size is used.
+	PageSize int
+	// Delimiter restricts listing to a single level, analogous to GCS/S3
+	// delimiter semantics. When non-empty, GCSStorage and S3Storage use it in
+	// place of the default "/" and apply it even when Recursive is true.
+	// LocalStorage ignores it.
+	Delimiter string
+}
+
+// URLSigner is an optional capability implemented by storage backends that can
+// generate time-limited URLs for direct client access (upload or download)
+// without proxying the data through the application server.
+type URLSigner interface {
+	// SignedURL returns a signed URL for the object with the specified name.
+	SignedURL(ctx context.Context, name string, opts *SignURLOptions) (string, error)
+}
+
+// DefaultSignedURLExpiry is the expiry used for a signed URL when
+// SignURLOptions.Expires is zero.
+const DefaultSignedURLExpiry = 15 * time.Minute
+
+// SignURLOptions holds optional settings for generating a signed URL.
+type SignURLOptions struct {
+	// Expires is how long the URL remains valid. Zero means DefaultSignedURLExpiry.
+	Expires time.Duration
+	// Method is the HTTP method the URL is valid for, e.g. "GET" or "PUT".
+	// Zero value means "GET".
+	Method string
+	// ContentType is the Content-Type the caller must use when uploading
+	// through a "PUT" URL.
+	ContentType string
+	// ResponseHeaders are additional response headers to include for a "GET" URL.
+	ResponseHeaders map[string]string
 }
 
 // ObjectInfo holds detailed information about an object in the storage.
@@ -46,4 +168,10 @@ type ObjectInfo struct {
 	UpdatedAt time.Time
 	// Metadata is the metadata associated with the object.
 	Metadata map[string]string
+	// ETag is the entity tag of the object's current contents, if the backend
+	// provides one.
+	ETag string
+	// Generation is the backend-specific generation/version number of the
+	// object's current contents, if the backend provides one.
+	Generation int64
 }
@@ -0,0 +1,138 @@
+package harestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// StorageSet is a registry of named Storage instances that lets operations be
+// composed across multiple backends, e.g. to migrate or replicate objects
+// between a local staging area and a cloud bucket.
+type StorageSet struct {
+	storages map[string]Storage
+}
+
+// NewStorageSet creates an empty StorageSet.
+func NewStorageSet() *StorageSet {
+	return &StorageSet{
+		storages: make(map[string]Storage),
+	}
+}
+
+// Register adds storage to the set, keyed by its Name().
+func (s *StorageSet) Register(storage Storage) {
+	s.storages[storage.Name()] = storage
+}
+
+// Get returns the registered Storage with the specified name.
+func (s *StorageSet) Get(name string) (Storage, bool) {
+	storage, ok := s.storages[name]
+
+	return storage, ok
+}
+
+// CopyBetween copies an object from srcName in the storage registered as
+// srcStorage to dstName in the storage registered as dstStorage.
+//
+// When both names resolve to the same underlying backend and bucket, the
+// backend's own server-side Copy is used. Otherwise the object is streamed
+// through this process via Get and Put.
+func (s *StorageSet) CopyBetween(ctx context.Context, srcStorage string, srcName string, dstStorage string, dstName string, opts *CopyOptions) error {
+	src, ok := s.Get(srcStorage)
+	if !ok {
+		return fmt.Errorf("storage %q not registered", srcStorage)
+	}
+
+	dst, ok := s.Get(dstStorage)
+	if !ok {
+		return fmt.Errorf("storage %q not registered", dstStorage)
+	}
+
+	if sameBackend(src, dst) {
+		err := src.Copy(ctx, srcName, dstName, opts)
+		if err != nil {
+			return fmt.Errorf("failed to copy %q to %q: %w", srcName, dstName, err)
+		}
+
+		return nil
+	}
+
+	if opts != nil && !opts.OverwriteIfExists {
+		_, err := dst.Stat(ctx, dstName)
+
+		switch {
+		case err == nil:
+			return fmt.Errorf("%w: destination %q already exists", ErrPreconditionFailed, dstName)
+		case isNotFoundErr(err):
+			// Destination doesn't exist; proceed with the copy.
+		default:
+			return fmt.Errorf("failed to stat %q on %q: %w", dstName, dst.Name(), err)
+		}
+	}
+
+	r, err := src.Get(ctx, srcName)
+	if err != nil {
+		return fmt.Errorf("failed to open %q on %q: %w", srcName, src.Name(), err)
+	}
+
+	defer r.Close()
+
+	putOpts := &PutOptions{}
+
+	if opts != nil && opts.PreserveMetadata {
+		info, err := src.Stat(ctx, srcName)
+		if err != nil {
+			return fmt.Errorf("failed to stat %q on %q: %w", srcName, src.Name(), err)
+		}
+
+		putOpts.Metadata = info.Metadata
+	}
+
+	_, err = dst.Put(ctx, dstName, r, putOpts)
+	if err != nil {
+		return fmt.Errorf("failed to write %q on %q: %w", dstName, dst.Name(), err)
+	}
+
+	return nil
+}
+
+// sameBackend reports whether src and dst are the same kind of backend
+// pointed at the same bucket (or root directory, for LocalStorage). Two
+// Storage values registered under different names but backed by the same
+// bucket still qualify, since what matters for server-side Copy is the
+// bucket, not the registry name.
+func sameBackend(src, dst Storage) bool {
+	switch src := src.(type) {
+	case *GCSStorage:
+		dst, ok := dst.(*GCSStorage)
+
+		return ok && src.bucketName == dst.bucketName
+	case *S3Storage:
+		dst, ok := dst.(*S3Storage)
+
+		return ok && src.bucketName == dst.bucketName
+	case *LocalStorage:
+		dst, ok := dst.(*LocalStorage)
+
+		return ok && src.rootDir == dst.rootDir
+	default:
+		return false
+	}
+}
+
+// isNotFoundErr reports whether err indicates that an object doesn't exist,
+// across any of the Storage backends' Stat implementations.
+func isNotFoundErr(err error) bool {
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+
+	var notFound *types.NotFound
+
+	return errors.As(err, &notFound)
+}